@@ -0,0 +1,143 @@
+package zipserver
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+)
+
+// overlayWinner pairs a zip.File with the *zip.Reader it was opened from, since Overlay resolves each
+// request against whichever archive holds the winning entry for that name.
+type overlayWinner struct {
+	zr *zip.Reader
+	zf *zip.File
+}
+
+// Overlay serves the union of several ZIP archives as a single http.Handler, resolving each request against
+// readers in the order given, last-wins: if the same name appears in more than one archive, the entry from
+// the reader passed last replaces earlier ones (pass readers in reverse order for first-wins semantics). The
+// winning entry gets the same deflate/gzip passthrough and ETag/conditional-request handling Handler provides,
+// so a patch archive layered on top of a base one transparently replaces a file with the same
+// Content-Encoding/Content-Length semantics.
+//
+// Unlike Handler, Overlay resolves individual file paths only: there is no merged directory listing, and a
+// request for "" or "/" is served as "index.html" without consulting any archive's own top-level directory.
+func Overlay(readers ...*zip.Reader) http.Handler {
+	winners := make(map[string]overlayWinner)
+	for _, zr := range readers {
+		for i := range zr.File {
+			winners[zr.File[i].Name] = overlayWinner{zr, zr.File[i]}
+		}
+	}
+
+	// Mirrors Handler's own fallback: srv serves winning entries straight off their owning *zip.Reader,
+	// while srvSeek0 routes through seekableFile for the rare case http.ServeContent needs to sniff the
+	// content type and seek back to the start, which zip.Reader files don't otherwise support.
+	srv := http.FileServer(http.FS(overlayFS{winners}))
+	srvSeek0 := http.FileServer(http.FS(seekableOverlayFS{winners}))
+	fallbackServe := func(w http.ResponseWriter, r *http.Request) {
+		if mime.TypeByExtension(path.Ext(r.URL.Path)) == "" {
+			srvSeek0.ServeHTTP(w, r)
+			return
+		}
+		srv.ServeHTTP(w, r)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		w.Header().Set("Accept-Ranges", "none")
+
+		key := strings.TrimPrefix(r.URL.Path, "/")
+		if key == "" {
+			key = "index.html"
+		}
+		win, ok := winners[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		zf := win.zf
+
+		lastModified := zf.Modified.UTC()
+		w.Header().Set("ETag", etag(zf))
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		if notModified(r, w.Header().Get("ETag"), lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		acceptEncoding := r.Header.Get("Accept-Encoding")
+		var encoding string
+		switch {
+		case strings.Contains(acceptEncoding, "deflate"):
+			encoding = "deflate"
+		case strings.Contains(acceptEncoding, "gzip"):
+			encoding = "gzip"
+		}
+		if r.Method == http.MethodGet && r.Header.Get("Range") == "" && encoding != "" && zf.Method == zip.Deflate {
+			if rd, err := zf.OpenRaw(); err == nil {
+				servePassthrough(w, rd, zf, encoding)
+				return
+			}
+		}
+
+		// http.FileServer redirects any path ending in "/index.html" and rejects a "/" that resolves to a
+		// regular file, so index.html entries skip fallbackServe and are served from memory here.
+		if path.Base(key) == "index.html" {
+			rc, err := zf.Open()
+			if err == nil {
+				var b []byte
+				b, err = io.ReadAll(rc)
+				rc.Close()
+				if err == nil {
+					http.ServeContent(w, r, key, lastModified, bytes.NewReader(b))
+					return
+				}
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		fallbackServe(w, r)
+	})
+}
+
+// overlayFS resolves names against whichever *zip.Reader holds that name's winning entry, so
+// http.FileServer can serve the overlay's fallback path without knowing the readers aren't one archive.
+type overlayFS struct{ winners map[string]overlayWinner }
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	key := name
+	if key == "." {
+		key = "index.html"
+	}
+	win, ok := o.winners[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return win.zr.Open(key)
+}
+
+// seekableOverlayFS is overlayFS wrapped the same way seekableFS wraps a single *zip.Reader: opened files
+// pretend to support Seek(0, io.SeekStart) by re-opening from the winning entry's own reader.
+type seekableOverlayFS struct{ winners map[string]overlayWinner }
+
+func (o seekableOverlayFS) Open(name string) (fs.File, error) {
+	key := name
+	if key == "." {
+		key = "index.html"
+	}
+	win, ok := o.winners[key]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	file, err := win.zr.Open(key)
+	if err != nil {
+		return nil, err
+	}
+	return &seekableFile{File: file, zr: win.zr, name: key}, nil
+}
@@ -0,0 +1,104 @@
+package zipserver_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"artyom.dev/zipserver"
+)
+
+func buildZip(t *testing.T, files map[string]string) *zip.Reader {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	for name, content := range files {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewReader(buf.Bytes())
+	zr, err := zip.NewReader(rd, rd.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return zr
+}
+
+func TestOverlay_fallback(t *testing.T) {
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: "raw.bin", Method: zip.Store})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte("stored-raw")); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	rd := bytes.NewReader(buf.Bytes())
+	zr, err := zip.NewReader(rd, rd.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := zipserver.Overlay(zr)
+
+	// A zip.Store entry never matches the deflate/gzip passthrough branch, so this always exercises the
+	// fallback path; a plain GET with no Accept-Encoding would too.
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/raw.bin", nil)
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, req)
+	resp := w2.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+	got, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if string(got) != "stored-raw" {
+		t.Fatalf("got %q, want %q", got, "stored-raw")
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	base := buildZip(t, map[string]string{"a.txt": "base-a", "b.txt": "base-b"})
+	patch := buildZip(t, map[string]string{"a.txt": "patch-a"})
+	handler := zipserver.Overlay(base, patch)
+
+	want := map[string]string{"a.txt": "patch-a", "b.txt": "base-b"}
+	for name, content := range want {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/"+name, nil)
+		req.Header.Set("Accept-Encoding", "deflate")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		resp := w.Result()
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("%s: unexpected status: %s", name, resp.Status)
+		}
+		fr := flate.NewReader(resp.Body)
+		got, err := io.ReadAll(fr)
+		fr.Close()
+		if err != nil {
+			t.Fatalf("%s: decompressing body: %v", name, err)
+		}
+		if string(got) != content {
+			t.Fatalf("%s: got %q, want %q (patch archive should win)", name, got, content)
+		}
+	}
+}
@@ -16,7 +16,9 @@ package zipserver
 
 import (
 	"archive/zip"
+	"encoding/binary"
 	"errors"
+	"fmt"
 	"io"
 	"io/fs"
 	"mime"
@@ -24,25 +26,27 @@ import (
 	"path"
 	"strconv"
 	"strings"
-	"sync"
+	"time"
 )
 
 // Handler wraps *zip.Reader, providing HTTP access to its contents.
 // If an incoming HTTP request announces support for compressed content with “Accept-Encoding: deflate” header, and a requested file inside a ZIP archive is compressed with Deflate method, Handler serves such file to the client as a “Content-Encoding: deflate” response.
+// If the client instead announces “Accept-Encoding: gzip” (and not “deflate”), Handler wraps the same raw Deflate stream in a gzip envelope on the fly, without re-compressing it.
+// Every response carries a strong ETag derived from the entry's CRC32, so conditional requests (“If-None-Match”, “If-Modified-Since”) are answered with 304 Not Modified without re-reading the entry.
 func Handler(z *zip.Reader) http.Handler {
 	// deflate-compressed files, name to index in z.File
 	m := make(map[string]int)
-	srv := http.FileServer(http.FS(z))
+	// every file, name to index in z.File; used to compute ETags regardless of storage method
+	all := make(map[string]int, len(z.File))
 	for i := range z.File {
-		if z.File[i].Method != zip.Deflate {
-			continue
+		all[z.File[i].Name] = i
+		if z.File[i].Method == zip.Deflate {
+			m[z.File[i].Name] = i
 		}
-		m[z.File[i].Name] = i
-	}
-	if len(m) == 0 {
-		return srv
 	}
 
+	srv := http.FileServer(http.FS(z))
+
 	// when content-type cannot be derived from the file name, http.serveContent
 	// reads a small buffer from the file to sniff the content type, and then tries
 	// to seek back to the start. zip.Reader files don't support seeking, so route
@@ -62,39 +66,116 @@ func Handler(z *zip.Reader) http.Handler {
 		w.Header().Add("Vary", "Accept-Encoding")
 		w.Header().Set("Accept-Ranges", "none")
 
-		if r.Method != http.MethodGet ||
-			r.Header.Get("Range") != "" ||
-			!strings.Contains(r.Header.Get("Accept-Encoding"), "deflate") {
-			fallbackServe(w, r)
-			return
-		}
-
 		key := strings.TrimPrefix(r.URL.Path, "/")
 		if key == "" {
 			key = "index.html"
 		}
+		if i, ok := all[key]; ok {
+			// setting ETag ahead of delegating to srv/srvSeek0 lets http.ServeContent's
+			// own conditional-request handling answer If-None-Match for the fallback path too.
+			w.Header().Set("ETag", etag(z.File[i]))
+		}
+
+		if r.Method != http.MethodGet || r.Header.Get("Range") != "" {
+			fallbackServe(w, r)
+			return
+		}
+		acceptEncoding := r.Header.Get("Accept-Encoding")
+		var encoding string
+		switch {
+		case strings.Contains(acceptEncoding, "deflate"):
+			encoding = "deflate"
+		case strings.Contains(acceptEncoding, "gzip"):
+			encoding = "gzip"
+		default:
+			fallbackServe(w, r)
+			return
+		}
+
 		i, ok := m[key]
 		if !ok {
 			fallbackServe(w, r)
 			return
 		}
+		zf := z.File[i]
 
-		rd, err := z.File[i].OpenRaw()
+		rd, err := zf.OpenRaw()
 		if err != nil {
 			fallbackServe(w, r)
 			return
 		}
 
-		w.Header().Set("Content-Type", conjureContentType(z.File[i]))
-		w.Header().Set("Content-Length", strconv.FormatUint(z.File[i].CompressedSize64, 10))
-		w.Header().Set("Content-Encoding", "deflate")
-		w.Header().Set("Last-Modified", z.File[i].Modified.UTC().Format(http.TimeFormat))
-		b := bufPool.Get().(*[]byte)
-		io.CopyBuffer(w, rd, *b)
-		bufPool.Put(b)
+		lastModified := zf.Modified.UTC()
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		if notModified(r, w.Header().Get("ETag"), lastModified) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		servePassthrough(w, rd, zf, encoding)
 	})
 }
 
+// servePassthrough writes zf's content to w as a response with Content-Encoding set to encoding, streaming
+// rd — zf's raw, still-compressed bytes as returned by zf.OpenRaw — without decompressing or recompressing
+// them. encoding is either "deflate", in which case rd is copied as-is, or "gzip", in which case rd is
+// wrapped in a gzip envelope built from zf's already-known CRC32 and sizes. Handler and Overlay both call
+// this once content negotiation, ETag and conditional-request handling have already run.
+func servePassthrough(w http.ResponseWriter, rd io.Reader, zf *zip.File, encoding string) {
+	w.Header().Set("Content-Type", conjureContentType(zf))
+	w.Header().Set("Content-Encoding", encoding)
+	if encoding == "deflate" {
+		w.Header().Set("Content-Length", strconv.FormatUint(zf.CompressedSize64, 10))
+		// io.Copy picks rd's WriteTo or w's ReadFrom over a user-space bounce when either is available;
+		// net/http's ResponseWriter implements ReaderFrom.
+		io.Copy(w, rd)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatUint(10+zf.CompressedSize64+8, 10))
+	var hdr [10]byte
+	hdr[0], hdr[1], hdr[2] = 0x1f, 0x8b, 0x08
+	binary.LittleEndian.PutUint32(hdr[4:8], uint32(zf.Modified.Unix()))
+	hdr[9] = 0xff
+	w.Write(hdr[:])
+	io.Copy(w, rd)
+	var trailer [8]byte
+	binary.LittleEndian.PutUint32(trailer[0:4], zf.CRC32)
+	binary.LittleEndian.PutUint32(trailer[4:8], uint32(zf.UncompressedSize64))
+	w.Write(trailer[:])
+}
+
+// etag derives a strong ETag from zf's CRC32 and compressed size, which are read straight from the
+// central directory and stay stable across restarts and across rebuilt archives whose contents didn't change.
+func etag(zf *zip.File) string {
+	return fmt.Sprintf(`"%x-%d"`, zf.CRC32, zf.CompressedSize64)
+}
+
+// notModified reports whether r's conditional request headers show the client already holds the response
+// identified by etag and lastModified. If-None-Match takes precedence over If-Modified-Since, per RFC 7232.
+func notModified(r *http.Request, etag string, lastModified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag == "" {
+			return false
+		}
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+			if tag == "*" || tag == etag {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" {
+		t, err := http.ParseTime(ims)
+		if err != nil {
+			return false
+		}
+		return !lastModified.Truncate(time.Second).After(t)
+	}
+	return false
+}
+
 func conjureContentType(zf *zip.File) string {
 	if s := mime.TypeByExtension(path.Ext(zf.Name)); s != "" {
 		return s
@@ -109,13 +190,6 @@ func conjureContentType(zf *zip.File) string {
 	return http.DetectContentType(b[:i])
 }
 
-var bufPool = sync.Pool{
-	New: func() interface{} {
-		b := make([]byte, 32*1024)
-		return &b
-	},
-}
-
 type seekableFS struct{ *zip.Reader }
 
 func (s seekableFS) Open(name string) (fs.File, error) {
@@ -149,3 +223,9 @@ func (f *seekableFile) Seek(offset int64, whence int) (int64, error) {
 	f.File = file
 	return 0, nil
 }
+
+// seekableFile intentionally does not implement io.WriterTo: its only callers go through
+// http.FileServer, which reaches it via net/http's serveContent and io.CopyN, and io.CopyN wraps its
+// source in an io.LimitedReader before the copy — io.Copy only looks for WriterTo on that immediate
+// argument, so a WriteTo method here would never be reached. Even if it were, the fs.File returned by
+// zip.Reader.Open doesn't implement io.WriterTo either, so there'd be nothing to smuggle through.
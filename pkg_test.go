@@ -4,6 +4,7 @@ import (
 	"archive/zip"
 	"bytes"
 	"compress/flate"
+	"compress/gzip"
 	"io"
 	"mime"
 	"net/http"
@@ -49,6 +50,37 @@ func TestHandler(t *testing.T) {
 	}
 }
 
+func TestHandler_gzip(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	zipserver.Handler(zipFile()).ServeHTTP(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+	if s := resp.Header.Get("Content-Encoding"); s != "gzip" {
+		t.Fatalf("unexpected Content-Encoding value (want gzip): %q", s)
+	}
+	gr, err := gzip.NewReader(resp.Body)
+	if err != nil {
+		t.Fatalf("reading gzip header: %v", err)
+	}
+	defer gr.Close()
+	got, err := io.ReadAll(gr)
+	if err != nil {
+		t.Fatalf("decompressing body: %v", err)
+	}
+	want, err := os.ReadFile(testFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("payloads differ (got %d bytes, want %d bytes)", len(got), len(want))
+	}
+}
+
 func TestHandler_seekableFile(t *testing.T) {
 	if ct := mime.TypeByExtension(path.Ext(testFileNoSuffix)); ct != "" {
 		t.Fatalf("got non-empty mime type for file named %q: %q", testFileNoSuffix, ct)
@@ -83,6 +115,56 @@ func TestHandler_seekableFile(t *testing.T) {
 	}
 }
 
+func TestHandler_etag(t *testing.T) {
+	handler := zipserver.Handler(zipFile())
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	resp := w.Result()
+	defer resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		t.Fatal("expected a non-empty ETag header")
+	}
+
+	req2 := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
+	req2.Header.Set("Accept-Encoding", "deflate")
+	req2.Header.Set("If-None-Match", etag)
+	w2 := httptest.NewRecorder()
+	handler.ServeHTTP(w2, req2)
+	resp2 := w2.Result()
+	defer resp2.Body.Close()
+	if resp2.StatusCode != http.StatusNotModified {
+		t.Fatalf("unexpected status for If-None-Match on passthrough path: %s", resp2.Status)
+	}
+	if s := resp2.Header.Get("ETag"); s != etag {
+		t.Fatalf("304 response carries a different ETag: %q", s)
+	}
+
+	// the same mechanism should apply on the fallback (unknown-mime, re-open-based) serving path.
+	req3 := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileNoSuffix, nil)
+	w3 := httptest.NewRecorder()
+	handler.ServeHTTP(w3, req3)
+	resp3 := w3.Result()
+	defer resp3.Body.Close()
+	fallbackEtag := resp3.Header.Get("ETag")
+	if fallbackEtag == "" {
+		t.Fatal("expected a non-empty ETag header on the fallback path")
+	}
+
+	req4 := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileNoSuffix, nil)
+	req4.Header.Set("If-None-Match", fallbackEtag)
+	w4 := httptest.NewRecorder()
+	handler.ServeHTTP(w4, req4)
+	resp4 := w4.Result()
+	defer resp4.Body.Close()
+	if resp4.StatusCode != http.StatusNotModified {
+		t.Fatalf("unexpected status for If-None-Match on fallback path: %s", resp4.Status)
+	}
+}
+
 func BenchmarkHandler(b *testing.B) {
 	handler := zipserver.Handler(zipFile())
 	req := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
@@ -100,6 +182,31 @@ func BenchmarkHandler(b *testing.B) {
 	}
 }
 
+// BenchmarkHandler_realServer exercises the passthrough copy through an actual net/http server, whose
+// http.ResponseWriter implements io.ReaderFrom, unlike httptest.ResponseRecorder used by BenchmarkHandler.
+func BenchmarkHandler_realServer(b *testing.B) {
+	srv := httptest.NewServer(zipserver.Handler(zipFile()))
+	defer srv.Close()
+	req, err := http.NewRequest(http.MethodGet, srv.URL+"/"+testFileName, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			b.Fatalf("unexpected status: %s", resp.Status)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+}
+
 const testFileName = "LICENSE.txt"
 const testFileNoSuffix = "unknown"
 
@@ -0,0 +1,225 @@
+package zipserver
+
+import (
+	"archive/zip"
+	"container/list"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// rangeBlockSize is the granularity at which HandlerFromURL fetches and caches remote bytes.
+const rangeBlockSize = 64 * 1024
+
+// Option configures a Handler built from a remote source, such as the one returned by HandlerFromURL.
+type Option func(*remoteConfig)
+
+type remoteConfig struct {
+	client      *http.Client
+	header      http.Header
+	cacheBlocks int
+}
+
+// WithHTTPClient overrides the http.Client used to fetch byte ranges. The default is http.DefaultClient.
+func WithHTTPClient(c *http.Client) Option {
+	return func(cfg *remoteConfig) { cfg.client = c }
+}
+
+// WithHeader sets an additional header, such as Authorization, sent with every request HandlerFromURL makes
+// against the remote archive. Calling it multiple times with the same key adds further values.
+func WithHeader(key, value string) Option {
+	return func(cfg *remoteConfig) {
+		if cfg.header == nil {
+			cfg.header = make(http.Header)
+		}
+		cfg.header.Add(key, value)
+	}
+}
+
+// WithCacheSize overrides the number of rangeBlockSize-aligned blocks kept in the in-memory LRU cache.
+// The default is 64 blocks (4 MiB), which is enough to amortize central directory traversal on most archives.
+func WithCacheSize(blocks int) Option {
+	return func(cfg *remoteConfig) { cfg.cacheBlocks = blocks }
+}
+
+// HandlerFromURL builds a Handler backed by a ZIP archive served over HTTP Range requests at url, without
+// requiring the whole archive to be downloaded first. The remote server must support conditionless
+// "Accept-Ranges: bytes" and report a stable Content-Length.
+//
+// Requests HandlerFromURL and the returned Handler make against url are bound to ctx; canceling ctx fails
+// in-flight and future range fetches, which is the intended way to release resources on handler shutdown.
+func HandlerFromURL(ctx context.Context, url string, opts ...Option) (http.Handler, error) {
+	cfg := remoteConfig{
+		client:      http.DefaultClient,
+		cacheBlocks: 64,
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range cfg.header {
+		req.Header[k] = v
+	}
+	resp, err := cfg.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("zipserver: HEAD %s: unexpected status %s", url, resp.Status)
+	}
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		return nil, fmt.Errorf("zipserver: %s does not advertise Accept-Ranges: bytes", url)
+	}
+	size := resp.ContentLength
+	if size <= 0 {
+		return nil, fmt.Errorf("zipserver: %s did not report a usable Content-Length", url)
+	}
+
+	ra := &rangeReaderAt{
+		ctx:    ctx,
+		url:    url,
+		client: cfg.client,
+		header: cfg.header,
+		size:   size,
+		cache:  newBlockCache(cfg.cacheBlocks),
+	}
+	zr, err := zip.NewReader(ra, size)
+	if err != nil {
+		return nil, err
+	}
+	return Handler(zr), nil
+}
+
+// rangeReaderAt implements io.ReaderAt over HTTP Range requests, backed by a small LRU cache of
+// rangeBlockSize-aligned blocks so that zip.Reader's central directory traversal and repeated small reads
+// from local file headers don't each cost a round trip.
+type rangeReaderAt struct {
+	ctx    context.Context
+	url    string
+	client *http.Client
+	header http.Header
+	size   int64
+
+	mu    sync.Mutex
+	cache *blockCache
+}
+
+func (r *rangeReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 || off >= r.size {
+		return 0, io.EOF
+	}
+	var n int
+	for n < len(p) {
+		blockOff := (off + int64(n)) / rangeBlockSize * rangeBlockSize
+		data, err := r.readBlock(blockOff)
+		if err != nil {
+			return n, err
+		}
+		skip := int(off + int64(n) - blockOff)
+		copied := copy(p[n:], data[skip:])
+		if copied == 0 {
+			break
+		}
+		n += copied
+	}
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (r *rangeReaderAt) readBlock(blockOff int64) ([]byte, error) {
+	r.mu.Lock()
+	if data, ok := r.cache.get(blockOff); ok {
+		r.mu.Unlock()
+		return data, nil
+	}
+	r.mu.Unlock()
+
+	end := blockOff + rangeBlockSize - 1
+	if end >= r.size {
+		end = r.size - 1
+	}
+	req, err := http.NewRequestWithContext(r.ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", blockOff, end))
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		return nil, fmt.Errorf("zipserver: GET %s: unexpected status %s", r.url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.cache.put(blockOff, data)
+	r.mu.Unlock()
+	return data, nil
+}
+
+// blockCache is a fixed-capacity LRU cache of byte blocks keyed by their offset.
+type blockCache struct {
+	capacity int
+	ll       *list.List
+	items    map[int64]*list.Element
+}
+
+type cacheEntry struct {
+	off  int64
+	data []byte
+}
+
+func newBlockCache(capacity int) *blockCache {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &blockCache{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[int64]*list.Element),
+	}
+}
+
+func (c *blockCache) get(off int64) ([]byte, bool) {
+	el, ok := c.items[off]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+func (c *blockCache) put(off int64, data []byte) {
+	if el, ok := c.items[off]; ok {
+		c.ll.MoveToFront(el)
+		el.Value.(*cacheEntry).data = data
+		return
+	}
+	el := c.ll.PushFront(&cacheEntry{off: off, data: data})
+	c.items[off] = el
+	for c.ll.Len() > c.capacity {
+		back := c.ll.Back()
+		if back == nil {
+			break
+		}
+		c.ll.Remove(back)
+		delete(c.items, back.Value.(*cacheEntry).off)
+	}
+}
@@ -0,0 +1,263 @@
+package zipserver_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"artyom.dev/zipserver"
+)
+
+// newRangeServer serves data as a range-capable HTTP resource, the way HandlerFromURL expects: a HEAD
+// reporting Content-Length and Accept-Ranges: bytes, and GETs honoring a "bytes=start-end" Range header.
+// Every request is passed to onRequest, if non-nil, before the response is written, so tests can observe
+// headers or count round trips.
+func newRangeServer(t *testing.T, data []byte, onRequest func(*http.Request)) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if onRequest != nil {
+			onRequest(r)
+		}
+		w.Header().Set("Accept-Ranges", "bytes")
+		if r.Method == http.MethodHead {
+			w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+			return
+		}
+		var start, end int
+		if _, err := fmt.Sscanf(strings.TrimPrefix(r.Header.Get("Range"), "bytes="), "%d-%d", &start, &end); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if end >= len(data) {
+			end = len(data) - 1
+		}
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, len(data)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(data[start : end+1])
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// zipWithFile builds a one-entry ZIP archive holding name -> content, stored with zip.Deflate.
+func zipWithFile(t *testing.T, name string, content []byte) []byte {
+	t.Helper()
+	buf := new(bytes.Buffer)
+	zw := zip.NewWriter(buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestHandlerFromURL(t *testing.T) {
+	b, err := os.ReadFile(testFileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := zipWithFile(t, testFileName, b)
+	srv := newRangeServer(t, data, nil)
+
+	h, err := zipserver.HandlerFromURL(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
+	req.Header.Set("Accept-Encoding", "deflate")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	resp := rec.Result()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+	if s := resp.Header.Get("Content-Encoding"); s != "deflate" {
+		t.Fatalf("unexpected Content-Encoding value (want deflate): %q", s)
+	}
+	fr := flate.NewReader(resp.Body)
+	defer fr.Close()
+	got, err := io.ReadAll(fr)
+	if err != nil {
+		t.Fatalf("decompressing body: %v", err)
+	}
+	if !bytes.Equal(got, b) {
+		t.Fatalf("payloads differ (got %d bytes, want %d bytes)", len(got), len(b))
+	}
+}
+
+// TestHandlerFromURL_cacheEviction uses an archive spanning several rangeBlockSize blocks together with a
+// small WithCacheSize, so a single request can't be satisfied from a cache large enough to hold every block
+// it touches: blockCache.put's eviction loop has to run, and re-reading the file afterwards has to re-fetch
+// blocks that were pushed out, rather than being served entirely from cache.
+func TestHandlerFromURL_cacheEviction(t *testing.T) {
+	// Random, not repetitive: Deflate barely shrinks this, so the compressed entry still spans several
+	// rangeBlockSize blocks and actually exercises ranged fetches instead of being served from one block.
+	content := make([]byte, 512*1024)
+	rand.New(rand.NewSource(1)).Read(content)
+	data := zipWithFile(t, "big.bin", content)
+
+	var gets int64
+	srv := newRangeServer(t, data, func(r *http.Request) {
+		if r.Method == http.MethodGet {
+			atomic.AddInt64(&gets, 1)
+		}
+	})
+
+	h, err := zipserver.HandlerFromURL(context.Background(), srv.URL, zipserver.WithCacheSize(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fetch := func() []byte {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost/big.bin", nil)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		resp := rec.Result()
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("unexpected status: %s", resp.Status)
+		}
+		got, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading body: %v", err)
+		}
+		return got
+	}
+
+	got := fetch()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("first read: payloads differ (got %d bytes, want %d bytes)", len(got), len(content))
+	}
+	firstGets := atomic.LoadInt64(&gets)
+	if firstGets < 3 {
+		t.Fatalf("expected several range GETs for a multi-block archive, got %d", firstGets)
+	}
+
+	got = fetch()
+	if !bytes.Equal(got, content) {
+		t.Fatalf("second read: payloads differ (got %d bytes, want %d bytes)", len(got), len(content))
+	}
+	if secondGets := atomic.LoadInt64(&gets) - firstGets; secondGets == 0 {
+		t.Fatal("second read served entirely from cache; expected blocks evicted by the 2-block cache to be re-fetched")
+	}
+}
+
+// TestHandlerFromURL_withHeader checks that WithHeader's header actually reaches the remote server, on both
+// the initial HEAD and the range GETs.
+func TestHandlerFromURL_withHeader(t *testing.T) {
+	data := zipWithFile(t, testFileName, []byte("hello"))
+
+	var sawHeadAuth, sawGetAuth bool
+	srv := newRangeServer(t, data, func(r *http.Request) {
+		switch r.Method {
+		case http.MethodHead:
+			sawHeadAuth = r.Header.Get("Authorization") == "Bearer token"
+		case http.MethodGet:
+			sawGetAuth = r.Header.Get("Authorization") == "Bearer token"
+		}
+	})
+
+	h, err := zipserver.HandlerFromURL(context.Background(), srv.URL, zipserver.WithHeader("Authorization", "Bearer token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "http://localhost/"+testFileName, nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if resp := rec.Result(); resp.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected status: %s", resp.Status)
+	}
+	if !sawHeadAuth {
+		t.Fatal("HEAD request did not carry the WithHeader value")
+	}
+	if !sawGetAuth {
+		t.Fatal("range GET did not carry the WithHeader value")
+	}
+}
+
+// TestHandlerFromURL_withHTTPClient checks that WithHTTPClient's client is the one actually used, by
+// wrapping http.DefaultTransport in a RoundTripper that records whether it was invoked.
+func TestHandlerFromURL_withHTTPClient(t *testing.T) {
+	data := zipWithFile(t, testFileName, []byte("hello"))
+	srv := newRangeServer(t, data, nil)
+
+	var used bool
+	client := &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		used = true
+		return http.DefaultTransport.RoundTrip(r)
+	})}
+
+	_, err := zipserver.HandlerFromURL(context.Background(), srv.URL, zipserver.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !used {
+		t.Fatal("HandlerFromURL did not use the client passed to WithHTTPClient")
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestHandlerFromURL_headErrors(t *testing.T) {
+	cases := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{
+			name: "non-OK status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.WriteHeader(http.StatusNotFound)
+			},
+		},
+		{
+			name: "missing Accept-Ranges",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Length", "10")
+			},
+		},
+		{
+			name: "Accept-Ranges not bytes",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Accept-Ranges", "none")
+				w.Header().Set("Content-Length", "10")
+			},
+		},
+		{
+			name: "unusable Content-Length",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Accept-Ranges", "bytes")
+				w.Header().Set("Content-Length", "0")
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+			if _, err := zipserver.HandlerFromURL(context.Background(), srv.URL); err == nil {
+				t.Fatal("expected an error, got nil")
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package zipserver
+
+import (
+	"archive/zip"
+	"os"
+)
+
+// OpenSelf opens the ZIP archive appended to the currently running executable — the "cat the zip onto the
+// binary" self-extracting trick — locating its end-of-central-directory record from the tail the same way
+// zip.OpenReader does for any file with extra leading bytes. It resolves the executable's path via
+// os.Executable, which reads /proc/self/exe on Linux and does the right platform-specific thing elsewhere,
+// rather than os.Args[0], which can be an unresolved relative name when the binary is launched via $PATH.
+func OpenSelf() (*zip.ReadCloser, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, err
+	}
+	return zip.OpenReader(path)
+}
@@ -0,0 +1,91 @@
+package zipserver_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"artyom.dev/zipserver"
+)
+
+// openSelfHelperEnv, when set in the environment, tells TestMain to act as the OpenSelf subprocess instead
+// of running the test suite: open the running executable as a ZIP and print a payload file's contents to
+// stdout. TestOpenSelf runs this helper out of a copy of the test binary with a ZIP appended to it, since
+// OpenSelf only has something real to find when its own binary carries one.
+const openSelfHelperEnv = "ZIPSERVER_OPENSELF_HELPER"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(openSelfHelperEnv) != "" {
+		os.Exit(runOpenSelfHelper())
+	}
+	os.Exit(m.Run())
+}
+
+func runOpenSelfHelper() int {
+	zr, err := zipserver.OpenSelf()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer zr.Close()
+	f, err := zr.Open(openSelfPayloadName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer f.Close()
+	if _, err := io.Copy(os.Stdout, f); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
+
+const (
+	openSelfPayloadName    = "payload.txt"
+	openSelfPayloadContent = "self-extracted payload"
+)
+
+func TestOpenSelf(t *testing.T) {
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := os.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := bytes.NewBuffer(bin)
+	zw := zip.NewWriter(buf)
+	w, err := zw.CreateHeader(&zip.FileHeader{Name: openSelfPayloadName, Method: zip.Deflate})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write([]byte(openSelfPayloadContent)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	combined := filepath.Join(t.TempDir(), "openself-helper")
+	if err := os.WriteFile(combined, buf.Bytes(), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(combined)
+	cmd.Env = append(os.Environ(), openSelfHelperEnv+"=1")
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("helper process: %v", err)
+	}
+	if got := string(out); got != openSelfPayloadContent {
+		t.Fatalf("got %q, want %q", got, openSelfPayloadContent)
+	}
+}